@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	sdk "github.com/operator-framework/operator-sdk/pkg/sdk"
+	k8sutil "github.com/operator-framework/operator-sdk/pkg/util/k8sutil"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/openshift/console-operator/pkg/operator"
+	"github.com/openshift/console-operator/pkg/stub"
+)
+
+const (
+	resyncPeriod = 5 * time.Second
+
+	// leaderElectionLease is the name of the Lease object the operator
+	// holds while it is the active instance.
+	leaderElectionLease = "console-operator-lock"
+	leaseDuration       = 15 * time.Second
+	renewDeadline       = 10 * time.Second
+	retryPeriod         = 2 * time.Second
+
+	// drainTimeoutSafetyMargin bounds how long main waits for a graceful
+	// OnStartedLeading shutdown (drain + lease release) to finish after
+	// le.Run returns, in case that goroutine is ever stuck despite
+	// operator.Shutdown's own drainTimeout. Comfortably longer than
+	// operator.Shutdown's drain window plus the lease release call.
+	drainTimeoutSafetyMargin = 40 * time.Second
+)
+
+func main() {
+	sdk.ExposeMetricsPort()
+
+	namespace, err := k8sutil.GetWatchNamespace()
+	if err != nil {
+		logrus.Fatalf("failed to get watch namespace: %v", err)
+	}
+
+	detectPodIPsSupport()
+
+	// leCtx controls both the leader election lease and, transitively, the
+	// reconcile loop: OnStartedLeading derives its own context from the one
+	// leaderelection hands it, which is cancelled whenever leCtx is
+	// cancelled (graceful shutdown) and whenever this instance
+	// involuntarily loses the lease (renewal failure, network partition,
+	// another instance stealing an expired lease).
+	leCtx, cancelLeaderElection := context.WithCancel(context.Background())
+	installSignalHandler(cancelLeaderElection)
+
+	// leadingDone is closed as the last step of OnStartedLeading, once the
+	// drain and lease release it's responsible for have actually finished.
+	// leaderelection.Run launches OnStartedLeading in its own goroutine and
+	// returns as soon as its renew loop ends, without waiting for that
+	// goroutine - so without blocking on leadingDone here, main would exit
+	// (killing the goroutine mid-drain) before the shutdown sequencing
+	// below ever got a chance to run.
+	leadingDone := make(chan struct{})
+
+	le, err := newLeaderElector(namespace, cancelLeaderElection, leadingDone)
+	if err != nil {
+		logrus.Fatalf("failed to set up leader election: %v", err)
+	}
+	le.Run(leCtx)
+
+	select {
+	case <-leadingDone:
+	case <-time.After(drainTimeoutSafetyMargin):
+		logrus.Error("timed out waiting for leader election shutdown to complete")
+	}
+}
+
+// installSignalHandler cancels the leader election context on
+// SIGTERM/SIGINT, which in turn tears down the reconcile loop. This removes
+// the gap where a replacement operator pod stalls waiting on a lease
+// abandoned by a killed process, since the outgoing operator keeps holding
+// the lease until its in-flight reconcile has actually finished.
+func installSignalHandler(cancel context.CancelFunc) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		sig := <-sigCh
+		logrus.Infof("received signal %s, shutting down", sig)
+		cancel()
+	}()
+}
+
+// newLeaderElector builds a leader elector backed by a coordination/v1
+// Lease. ReleaseOnCancel is deliberately left disabled: leaderelection.Run
+// releases the lease as soon as the context it's given is done, racing
+// operator.Shutdown's drain in a separate goroutine. Instead, releaseLease
+// is called ourselves from inside OnStartedLeading, after Shutdown has
+// returned, so the lease is never given up before in-flight reconciles
+// have actually drained. leadingDone is closed once that whole sequence
+// has finished, so the caller can block process exit on it.
+func newLeaderElector(namespace string, cancelLeaderElection context.CancelFunc, leadingDone chan struct{}) (*leaderelection.LeaderElector, error) {
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	kubeClient, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	id, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      leaderElectionLease,
+			Namespace: namespace,
+		},
+		Client: kubeClient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: id,
+		},
+	}
+
+	return leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		ReleaseOnCancel: false,
+		LeaseDuration:   leaseDuration,
+		RenewDeadline:   renewDeadline,
+		RetryPeriod:     retryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				// leaderelection.Run spawns this callback in its own
+				// goroutine and doesn't wait for it to return, so close
+				// leadingDone as the very last step - once the drain and
+				// lease release below have actually completed - so main
+				// can block process exit on it instead of racing it.
+				defer close(leadingDone)
+
+				// ctx is cancelled both on graceful shutdown (leCtx
+				// cancelled) and whenever this instance involuntarily loses
+				// the lease (renewal failure, network partition, another
+				// instance stealing an expired lease). Deriving sdkCtx from
+				// it, rather than from a separately signal-driven context,
+				// ensures the reconcile loop stops reconciling - and writing
+				// to the cluster - in either case.
+				sdkCtx, cancelSDK := context.WithCancel(ctx)
+				defer cancelSDK()
+
+				logrus.Infof("watching console.openshift.io/v1alpha1, Console, %s, %s", namespace, resyncPeriod)
+				sdk.Watch("console.openshift.io/v1alpha1", "Console", namespace, resyncPeriod)
+				sdk.Handle(stub.NewHandler())
+				sdk.Run(sdkCtx)
+
+				// sdk.Run only returns after sdkCtx is cancelled; drain any
+				// reconcile still in flight before giving up the lease. Only
+				// once that drain has actually completed do we release the
+				// lease ourselves (ReleaseOnCancel is off, so leaderelection
+				// won't race us to it) and tear down leCtx.
+				if err := operator.Shutdown(nil); err != nil {
+					logrus.Errorf("error during shutdown: %v", err)
+				}
+				releaseLease(lock, id)
+				cancelLeaderElection()
+			},
+			OnStoppedLeading: func() {
+				logrus.Info("stopped leading console operator leader election")
+			},
+		},
+	})
+}
+
+// releaseLease gives up the lease immediately, mirroring what
+// leaderelection's own ReleaseOnCancel path does internally, but called by
+// us only once we know it's actually safe to do so (operator.Shutdown has
+// returned). Clearing HolderIdentity and setting a 1-second
+// LeaseDurationSeconds lets the next instance acquire it right away rather
+// than waiting out the full LeaseDuration.
+func releaseLease(lock resourcelock.Interface, id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), renewDeadline)
+	defer cancel()
+
+	now := metav1.NewTime(time.Now())
+	if err := lock.Update(ctx, resourcelock.LeaderElectionRecord{
+		HolderIdentity:       "",
+		LeaseDurationSeconds: 1,
+		AcquireTime:          now,
+		RenewTime:            now,
+	}); err != nil {
+		logrus.Errorf("failed to release leader election lease held by %s: %v", id, err)
+	}
+}
+
+// detectPodIPsSupport checks the API server's version and disables the
+// operator's use of status.podIPs if it predates Kubernetes 1.16, where the
+// field doesn't exist. Any failure to determine the version is treated as
+// "supported" so the common case doesn't get penalized by a conservative
+// fallback.
+func detectPodIPsSupport() {
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		logrus.Warnf("could not load in-cluster config to check status.podIPs support, assuming supported: %v", err)
+		return
+	}
+	dc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		logrus.Warnf("could not create discovery client to check status.podIPs support, assuming supported: %v", err)
+		return
+	}
+	version, err := dc.ServerVersion()
+	if err != nil {
+		logrus.Warnf("could not get server version to check status.podIPs support, assuming supported: %v", err)
+		return
+	}
+
+	major, err := strconv.Atoi(version.Major)
+	if err != nil {
+		logrus.Warnf("could not parse API server major version %q to check status.podIPs support, assuming supported: %v", version.Major, err)
+		return
+	}
+	minor, err := strconv.Atoi(strings.TrimRight(version.Minor, "+"))
+	if err != nil {
+		logrus.Warnf("could not parse API server minor version %q to check status.podIPs support, assuming supported: %v", version.Minor, err)
+		return
+	}
+	if major == 1 && minor < 16 {
+		logrus.Infof("API server %s.%s does not advertise status.podIPs, disabling dual-stack downward API env var", version.Major, version.Minor)
+		operator.SetPodIPsSupported(false)
+	}
+}
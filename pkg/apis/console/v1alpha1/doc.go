@@ -0,0 +1,4 @@
+// Package v1alpha1 contains API Schema definitions for the console v1alpha1 API group
+// +k8s:deepcopy-gen=package,register
+// +groupName=console.openshift.io
+package v1alpha1
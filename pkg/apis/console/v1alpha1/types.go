@@ -0,0 +1,170 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Console is the configuration for the OpenShift web console, including the
+// number of replicas and how they should be exposed and scheduled.
+type Console struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConsoleSpec   `json:"spec"`
+	Status ConsoleStatus `json:"status,omitempty"`
+}
+
+// ConsoleSpec is the desired state of the console deployment.
+type ConsoleSpec struct {
+	// Count is the number of console replicas to run.
+	Count int32 `json:"count"`
+
+	// ManagementState indicates whether the operator actively manages the
+	// console resources, or leaves them untouched.
+	ManagementState string `json:"managementState,omitempty"`
+
+	// ReadinessProbe overrides the default readiness probe for the console
+	// container. Unset fields fall back to the operator's defaults.
+	// +optional
+	ReadinessProbe *ProbeSpec `json:"readinessProbe,omitempty"`
+
+	// LivenessProbe overrides the default liveness probe for the console
+	// container. Unset fields fall back to the operator's defaults.
+	// +optional
+	LivenessProbe *ProbeSpec `json:"livenessProbe,omitempty"`
+
+	// StartupProbe, when set, is added to the console container so that
+	// slow-starting bridges are not killed by the liveness probe before
+	// they finish OIDC discovery.
+	// +optional
+	StartupProbe *ProbeSpec `json:"startupProbe,omitempty"`
+
+	// Resources describes the compute resource requests and limits for the
+	// console container.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// NodeSelector constrains the console pods to nodes matching the given
+	// labels, e.g. to pin the console onto infra nodes.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations are applied to the console pods, allowing them to be
+	// scheduled onto nodes with matching taints.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity, when set, is applied to the console pods verbatim.
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// PriorityClassName, when set, is applied to the console pods.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// RolloutStrategy overrides the Deployment's RollingUpdate parameters.
+	// +optional
+	RolloutStrategy *RolloutStrategy `json:"rolloutStrategy,omitempty"`
+
+	// DualStack, when true, configures the bridge to also listen on the
+	// IPv6 wildcard address and injects POD_IPS from the downward API so it
+	// can bind to the correct address on a dual-stack cluster.
+	// +optional
+	DualStack bool `json:"dualStack,omitempty"`
+
+	// DrainTimeoutSeconds overrides how long the PreStop hook waits after
+	// signalling the bridge process before the container is considered
+	// terminated, giving in-flight requests a chance to complete. Defaults
+	// to 20 seconds.
+	// +optional
+	DrainTimeoutSeconds *int32 `json:"drainTimeoutSeconds,omitempty"`
+}
+
+// RolloutStrategy controls how many console pods may be created or taken
+// down at once during a rollout.
+type RolloutStrategy struct {
+	// MaxSurge is the maximum number of pods that can be created over
+	// Count during a rollout. Defaults to 25%.
+	// +optional
+	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+	// MaxUnavailable is the maximum number of pods that can be unavailable
+	// during a rollout. Defaults to 25%.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// ProbeSpec overrides the HTTP probe the operator configures on the console
+// container. Any field left unset keeps the operator's default for it.
+type ProbeSpec struct {
+	// Path is the HTTP path to probe. Defaults to /health.
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Port is the container port to probe. Defaults to 8443.
+	// +optional
+	Port int32 `json:"port,omitempty"`
+	// Scheme is HTTP or HTTPS. Defaults to HTTPS.
+	// +optional
+	Scheme string `json:"scheme,omitempty"`
+
+	// +optional
+	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
+	// +optional
+	PeriodSeconds int32 `json:"periodSeconds,omitempty"`
+	// +optional
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+	// +optional
+	SuccessThreshold int32 `json:"successThreshold,omitempty"`
+	// +optional
+	FailureThreshold int32 `json:"failureThreshold,omitempty"`
+}
+
+// ConsoleStatus is the most recently observed state of the console
+// deployment.
+type ConsoleStatus struct {
+	// Conditions describes the current state of the console deployment,
+	// following the standard Available/Progressing/Degraded operator
+	// conventions.
+	// +optional
+	Conditions []ConsoleCondition `json:"conditions,omitempty"`
+}
+
+// ConsoleConditionType is the set of condition types reported on a Console.
+type ConsoleConditionType string
+
+const (
+	// ConditionAvailable is true when the console Deployment has the
+	// desired number of available replicas.
+	ConditionAvailable ConsoleConditionType = "Available"
+	// ConditionProgressing is true while the operator is rolling out a
+	// change to the console Deployment.
+	ConditionProgressing ConsoleConditionType = "Progressing"
+	// ConditionDegraded is true when the console Deployment cannot reach
+	// the desired state, e.g. a pod is stuck in CrashLoopBackOff.
+	ConditionDegraded ConsoleConditionType = "Degraded"
+)
+
+// ConsoleCondition is a single observation of the console's state.
+type ConsoleCondition struct {
+	Type   ConsoleConditionType   `json:"type"`
+	Status corev1.ConditionStatus `json:"status"`
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ConsoleList is a list of Console resources.
+type ConsoleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Console `json:"items"`
+}
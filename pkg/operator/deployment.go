@@ -3,13 +3,13 @@ package operator
 import (
 	// standard lib
 	"fmt"
+	"reflect"
 	// 3rd party
 	"github.com/sirupsen/logrus"
 	// kubernetes
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	// openshift
@@ -20,8 +20,27 @@ import (
 
 const (
 	configMapResourceVersionAnnotation = "console.openshift.io/configmapversion"
+	// defaultDrainSeconds is how long the PreStop hook waits after
+	// signalling the bridge process, giving in-flight requests a chance to
+	// complete before the kubelet sends SIGKILL, when the CR doesn't
+	// override it via DrainTimeoutSeconds.
+	defaultDrainSeconds = 20
+	// gracePeriodSlackSecs is added on top of the drain window when
+	// computing TerminationGracePeriodSeconds so the pod is never killed
+	// out from under a still-draining bridge.
+	gracePeriodSlackSecs = 10
 )
 
+// drainSeconds returns how long the PreStop hook should wait after
+// signalling the bridge process, honoring cr.Spec.DrainTimeoutSeconds when
+// the cluster admin has set it.
+func drainSeconds(cr *v1alpha1.Console) int32 {
+	if cr.Spec.DrainTimeoutSeconds != nil {
+		return *cr.Spec.DrainTimeoutSeconds
+	}
+	return defaultDrainSeconds
+}
+
 func newConsoleDeployment(cr *v1alpha1.Console) *appsv1.Deployment {
 	labels := labelsForConsole()
 	meta := sharedMeta()
@@ -29,7 +48,7 @@ func newConsoleDeployment(cr *v1alpha1.Console) *appsv1.Deployment {
 	// tack on the deployment specific labels
 	// TODO: just make this another helper function, ensure things stay in sync
 	meta.Labels = labels
-	gracePeriod := int64(30)
+	gracePeriod := int64(drainSeconds(cr)) + gracePeriodSlackSecs
 
 	deployment := &appsv1.Deployment{
 		TypeMeta: metav1.TypeMeta{
@@ -42,6 +61,7 @@ func newConsoleDeployment(cr *v1alpha1.Console) *appsv1.Deployment {
 			Selector: &metav1.LabelSelector{
 				MatchLabels: labels,
 			},
+			Strategy: rolloutStrategy(cr),
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:   OpenShiftConsoleShortName,
@@ -51,11 +71,12 @@ func newConsoleDeployment(cr *v1alpha1.Console) *appsv1.Deployment {
 					},
 				},
 				Spec: corev1.PodSpec{
-					// NodeSelector:  corev1.NodeSelector{},
-					RestartPolicy: "Always",
-					SchedulerName: "default-scheduler",
-					//the values here may be openshift specific.
-					//Affinity: corev1.Affinity{ },
+					NodeSelector:                  cr.Spec.NodeSelector,
+					RestartPolicy:                 "Always",
+					SchedulerName:                 "default-scheduler",
+					Affinity:                      cr.Spec.Affinity,
+					Tolerations:                   cr.Spec.Tolerations,
+					PriorityClassName:             cr.Spec.PriorityClassName,
 					TerminationGracePeriodSeconds: &gracePeriod,
 					SecurityContext:               &corev1.PodSecurityContext{},
 					Containers: []corev1.Container{
@@ -70,6 +91,29 @@ func newConsoleDeployment(cr *v1alpha1.Console) *appsv1.Deployment {
 	return deployment
 }
 
+// rolloutStrategy builds a RollingUpdate strategy from cr.Spec.RolloutStrategy,
+// falling back to the Deployment default of 25% surge / 25% unavailable when
+// unset.
+func rolloutStrategy(cr *v1alpha1.Console) appsv1.DeploymentStrategy {
+	maxSurge := intstr.FromString("25%")
+	maxUnavailable := intstr.FromString("25%")
+	if rs := cr.Spec.RolloutStrategy; rs != nil {
+		if rs.MaxSurge != nil {
+			maxSurge = *rs.MaxSurge
+		}
+		if rs.MaxUnavailable != nil {
+			maxUnavailable = *rs.MaxUnavailable
+		}
+	}
+	return appsv1.DeploymentStrategy{
+		Type: appsv1.RollingUpdateDeploymentStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateDeployment{
+			MaxSurge:       &maxSurge,
+			MaxUnavailable: &maxUnavailable,
+		},
+	}
+}
+
 // deduplication, use the same volume config to generate Volumes, and VolumeMounts
 func consoleVolumes(vc []volumeConfig) []corev1.Volume {
 	vols := make([]corev1.Volume, len(vc))
@@ -112,6 +156,51 @@ func consoleVolumeMounts(vc []volumeConfig) []corev1.VolumeMount {
 	return volMountList
 }
 
+// podIPsSupported tracks whether the API server this operator is running
+// against advertises status.podIPs (added in Kubernetes 1.16). main sets
+// this once at startup via SetPodIPsSupported after checking the server
+// version; it defaults to true so the field is only disabled when we know
+// it's actually missing.
+var podIPsSupported = true
+
+// SetPodIPsSupported records whether the API server advertises
+// status.podIPs, so consoleDownwardEnv can fall back gracefully on older
+// clusters instead of injecting a fieldRef the apiserver will reject.
+func SetPodIPsSupported(supported bool) {
+	podIPsSupported = supported
+}
+
+// consoleDownwardEnv sources identity and network information from the
+// downward API so the bridge can bind to the correct address, including on
+// dual-stack clusters where 0.0.0.0 isn't enough.
+func consoleDownwardEnv(cr *v1alpha1.Console) []corev1.EnvVar {
+	env := []corev1.EnvVar{
+		downwardEnvVar("POD_NAME", "metadata.name"),
+		downwardEnvVar("POD_NAMESPACE", "metadata.namespace"),
+		downwardEnvVar("POD_IP", "status.podIP"),
+		downwardEnvVar("NODE_NAME", "spec.nodeName"),
+	}
+	if cr.Spec.DualStack {
+		if podIPsSupported {
+			env = append(env, downwardEnvVar("POD_IPS", "status.podIPs"))
+		} else {
+			logrus.Warnf("DualStack requested but the API server does not advertise status.podIPs; falling back to POD_IP only")
+		}
+	}
+	return env
+}
+
+func downwardEnvVar(name, fieldPath string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{
+				FieldPath: fieldPath,
+			},
+		},
+	}
+}
+
 func image(base string, version string) string {
 	if version != "" {
 		return fmt.Sprintf("%s:%s", base, version)
@@ -141,46 +230,107 @@ func livenessProbe() *corev1.Probe {
 	return probe
 }
 
+// startupProbe is more forgiving than livenessProbe, giving a bridge on a
+// constrained node up to FailureThreshold*PeriodSeconds to finish OIDC
+// discovery before the liveness probe starts killing it.
+func startupProbe() *corev1.Probe {
+	probe := defaultProbe()
+	probe.PeriodSeconds = 10
+	probe.FailureThreshold = 30
+	return probe
+}
+
+// applyProbeOverrides merges the user-supplied override over probe's
+// defaults, field by field, leaving the defaults in place for anything the
+// user didn't set.
+func applyProbeOverrides(probe *corev1.Probe, override *v1alpha1.ProbeSpec) *corev1.Probe {
+	if override == nil {
+		return probe
+	}
+	if override.Path != "" {
+		probe.HTTPGet.Path = override.Path
+	}
+	if override.Port != 0 {
+		probe.HTTPGet.Port = intstr.FromInt(int(override.Port))
+	}
+	if override.Scheme != "" {
+		probe.HTTPGet.Scheme = corev1.URIScheme(override.Scheme)
+	}
+	if override.InitialDelaySeconds != 0 {
+		probe.InitialDelaySeconds = override.InitialDelaySeconds
+	}
+	if override.PeriodSeconds != 0 {
+		probe.PeriodSeconds = override.PeriodSeconds
+	}
+	if override.TimeoutSeconds != 0 {
+		probe.TimeoutSeconds = override.TimeoutSeconds
+	}
+	if override.SuccessThreshold != 0 {
+		probe.SuccessThreshold = override.SuccessThreshold
+	}
+	if override.FailureThreshold != 0 {
+		probe.FailureThreshold = override.FailureThreshold
+	}
+	return probe
+}
+
+// consolePreStopLifecycle sends SIGTERM to the bridge process and gives it
+// a configurable drain window to finish in-flight requests before the
+// kubelet proceeds with the rest of the termination sequence. Without this,
+// upgrades can surface 502s for requests in flight when the container is
+// killed.
+func consolePreStopLifecycle(cr *v1alpha1.Console) *corev1.Lifecycle {
+	return &corev1.Lifecycle{
+		PreStop: &corev1.Handler{
+			Exec: &corev1.ExecAction{
+				Command: []string{
+					"/bin/sh", "-c",
+					fmt.Sprintf("kill -TERM 1; sleep %d", drainSeconds(cr)),
+				},
+			},
+		},
+	}
+}
+
 func consoleContainer(cr *v1alpha1.Console) corev1.Container {
 	volumeMounts := consoleVolumeMounts(volumeConfigList)
 
+	command := []string{
+		"/opt/bridge/bin/bridge",
+		"--public-dir=/opt/bridge/static",
+		"--config=/var/console-config/console-config.yaml",
+	}
+	if cr.Spec.DualStack {
+		// Bind the bridge on the wildcard IPv6 address too, so it accepts
+		// connections over either family on a dual-stack cluster.
+		command = append(command, "--listen=[::]:8443")
+	}
+
 	return corev1.Container{
 		Image:           GetImageEnv(),
 		ImagePullPolicy: corev1.PullPolicy("IfNotPresent"),
 		Name:            OpenShiftConsoleShortName,
-		Command: []string{
-			"/opt/bridge/bin/bridge",
-			"--public-dir=/opt/bridge/static",
-			"--config=/var/console-config/console-config.yaml",
-		},
-		// TODO: can probably remove, this is used for local dev
-		//Env: []corev1.EnvVar{{
-		//	Name:  publicURLName,
-		//	Value: consoleURL(),
-		//}},
+		Command:         command,
+		Env:             consoleDownwardEnv(cr),
 		Ports: []corev1.ContainerPort{{
 			Name:          consolePortName,
 			Protocol:      corev1.ProtocolTCP,
 			ContainerPort: consolePort,
 		}},
 		VolumeMounts:             volumeMounts,
-		ReadinessProbe:           defaultProbe(),
-		LivenessProbe:            livenessProbe(),
+		ReadinessProbe:           applyProbeOverrides(defaultProbe(), cr.Spec.ReadinessProbe),
+		LivenessProbe:            applyProbeOverrides(livenessProbe(), cr.Spec.LivenessProbe),
+		StartupProbe:             applyProbeOverrides(startupProbe(), cr.Spec.StartupProbe),
+		Lifecycle:                consolePreStopLifecycle(cr),
 		TerminationMessagePath:   "/dev/termination-log",
 		TerminationMessagePolicy: corev1.TerminationMessagePolicy("File"),
-		Resources: corev1.ResourceRequirements{
-			Limits: map[corev1.ResourceName]resource.Quantity{
-				// TODO: fill these out
-				//	"cpu": int64(100),
-				//	"memory": int64(100)
-			},
-			Requests: map[corev1.ResourceName]resource.Quantity{},
-		},
+		Resources:                cr.Spec.Resources,
 	}
 
 }
 
 func UpdateDeployment(cr *v1alpha1.Console, cm *corev1.ConfigMap) (*appsv1.Deployment, error) {
+	wanted := newConsoleDeployment(cr)
 	d := newConsoleDeployment(cr)
 	changed := false
 	if err := sdk.Get(d); err != nil {
@@ -201,6 +351,36 @@ func UpdateDeployment(cr *v1alpha1.Console, cm *corev1.ConfigMap) (*appsv1.Deplo
 		changed = true
 	}
 
+	if probesChanged(d, wanted) {
+		// Probe overrides on the CR changed; push the new probes the same
+		// way a configmap change does, so the rollout actually picks them up.
+		syncProbes(d, wanted)
+		changed = true
+	}
+
+	if schedulingChanged(d, wanted) {
+		// Resources, NodeSelector, Tolerations, Affinity or
+		// PriorityClassName drifted from the CR; patch them in so the
+		// cluster admin's scheduling intent actually takes effect.
+		syncScheduling(d, wanted)
+		changed = true
+	}
+
+	if containersChanged(d, wanted) {
+		// Env, Command or the PreStop drain window drifted from the CR -
+		// most commonly DualStack toggling the downward API POD_IPS var and
+		// the --listen=[::]:8443 arg, or DrainTimeoutSeconds changing - so
+		// roll the new container spec out the same way a configmap change
+		// does.
+		syncContainers(d, wanted)
+		changed = true
+	}
+
+	if !reflect.DeepEqual(d.Spec.Strategy, wanted.Spec.Strategy) {
+		d.Spec.Strategy = wanted.Spec.Strategy
+		changed = true
+	}
+
 	if changed {
 		if err := sdk.Update(d); err != nil {
 			return nil, err
@@ -209,6 +389,66 @@ func UpdateDeployment(cr *v1alpha1.Console, cm *corev1.ConfigMap) (*appsv1.Deplo
 	return d, nil
 }
 
+// probesChanged reports whether the live deployment's console container
+// probes have drifted from what the CR currently wants.
+func probesChanged(current, wanted *appsv1.Deployment) bool {
+	c := current.Spec.Template.Spec.Containers[0]
+	w := wanted.Spec.Template.Spec.Containers[0]
+	return !reflect.DeepEqual(c.ReadinessProbe, w.ReadinessProbe) ||
+		!reflect.DeepEqual(c.LivenessProbe, w.LivenessProbe) ||
+		!reflect.DeepEqual(c.StartupProbe, w.StartupProbe)
+}
+
+func syncProbes(current, wanted *appsv1.Deployment) {
+	c := &current.Spec.Template.Spec.Containers[0]
+	w := wanted.Spec.Template.Spec.Containers[0]
+	c.ReadinessProbe = w.ReadinessProbe
+	c.LivenessProbe = w.LivenessProbe
+	c.StartupProbe = w.StartupProbe
+}
+
+// schedulingChanged reports whether the live deployment's resources or
+// scheduling constraints have drifted from what the CR currently wants.
+func schedulingChanged(current, wanted *appsv1.Deployment) bool {
+	cp := current.Spec.Template.Spec
+	wp := wanted.Spec.Template.Spec
+	return !reflect.DeepEqual(current.Spec.Template.Spec.Containers[0].Resources, wanted.Spec.Template.Spec.Containers[0].Resources) ||
+		!reflect.DeepEqual(cp.NodeSelector, wp.NodeSelector) ||
+		!reflect.DeepEqual(cp.Tolerations, wp.Tolerations) ||
+		!reflect.DeepEqual(cp.Affinity, wp.Affinity) ||
+		cp.PriorityClassName != wp.PriorityClassName
+}
+
+func syncScheduling(current, wanted *appsv1.Deployment) {
+	current.Spec.Template.Spec.Containers[0].Resources = wanted.Spec.Template.Spec.Containers[0].Resources
+	current.Spec.Template.Spec.NodeSelector = wanted.Spec.Template.Spec.NodeSelector
+	current.Spec.Template.Spec.Tolerations = wanted.Spec.Template.Spec.Tolerations
+	current.Spec.Template.Spec.Affinity = wanted.Spec.Template.Spec.Affinity
+	current.Spec.Template.Spec.PriorityClassName = wanted.Spec.Template.Spec.PriorityClassName
+}
+
+// containersChanged reports whether the live deployment's console container
+// Env, Command or Lifecycle, or the pod's TerminationGracePeriodSeconds
+// that follows the drain window, have drifted from what the CR currently
+// wants.
+func containersChanged(current, wanted *appsv1.Deployment) bool {
+	c := current.Spec.Template.Spec.Containers[0]
+	w := wanted.Spec.Template.Spec.Containers[0]
+	return !reflect.DeepEqual(c.Env, w.Env) ||
+		!reflect.DeepEqual(c.Command, w.Command) ||
+		!reflect.DeepEqual(c.Lifecycle, w.Lifecycle) ||
+		!reflect.DeepEqual(current.Spec.Template.Spec.TerminationGracePeriodSeconds, wanted.Spec.Template.Spec.TerminationGracePeriodSeconds)
+}
+
+func syncContainers(current, wanted *appsv1.Deployment) {
+	c := &current.Spec.Template.Spec.Containers[0]
+	w := wanted.Spec.Template.Spec.Containers[0]
+	c.Env = w.Env
+	c.Command = w.Command
+	c.Lifecycle = w.Lifecycle
+	current.Spec.Template.Spec.TerminationGracePeriodSeconds = wanted.Spec.Template.Spec.TerminationGracePeriodSeconds
+}
+
 func CreateConsoleDeployment(cr *v1alpha1.Console) (*appsv1.Deployment, error) {
 	d := newConsoleDeployment(cr)
 	if err := sdk.Create(d); err != nil && !errors.IsAlreadyExists(err) {
@@ -220,17 +460,47 @@ func CreateConsoleDeployment(cr *v1alpha1.Console) (*appsv1.Deployment, error) {
 }
 
 func ApplyDeployment(cr *v1alpha1.Console, cm *corev1.ConfigMap) (*appsv1.Deployment, error) {
+	// Mark this reconcile as in flight so Shutdown can wait for it to
+	// finish before the operator releases its leader election lease.
+	done := BeginReconcile()
+	defer done()
+
+	// Keep the PDB in lockstep with the Deployment so a voluntary node
+	// drain during a cluster upgrade never has a window where the console
+	// has no disruption protection at all.
+	if _, err := ApplyPDB(cr); err != nil {
+		return nil, err
+	}
+
 	d := newConsoleDeployment(cr)
 	err := sdk.Get(d)
 
 	if err != nil {
-		return CreateConsoleDeployment(cr)
+		d, err = CreateConsoleDeployment(cr)
+	} else {
+		d, err = UpdateDeployment(cr, cm)
+	}
+	if err != nil {
+		return nil, err
 	}
-	return UpdateDeployment(cr, cm)
+
+	// Report the rollout's progress on the CR and ask the caller to requeue
+	// with backoff while it is still in flight, so status reflects reality
+	// instead of claiming success the moment sdk.Update returns.
+	if statusErr := UpdateConsoleStatus(cr); statusErr != nil {
+		return d, statusErr
+	}
+	return d, nil
 }
 
 // Deletes the Console Deployment when the Console ManagementState is set to Removed
 func DeleteDeployment(cr *v1alpha1.Console) error {
+	done := BeginReconcile()
+	defer done()
+
+	if err := DeletePDB(cr); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
 	d := newConsoleDeployment(cr)
 	return sdk.Delete(d)
 }
@@ -0,0 +1,298 @@
+package operator
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/openshift/console-operator/pkg/apis/console/v1alpha1"
+)
+
+func TestApplyProbeOverrides(t *testing.T) {
+	tests := []struct {
+		name     string
+		override *v1alpha1.ProbeSpec
+		check    func(t *testing.T, probe *corev1.Probe)
+	}{
+		{
+			name:     "nil override leaves defaults untouched",
+			override: nil,
+			check: func(t *testing.T, probe *corev1.Probe) {
+				if probe.HTTPGet.Path != "/health" {
+					t.Errorf("Path = %q, want /health", probe.HTTPGet.Path)
+				}
+				if probe.PeriodSeconds != 10 {
+					t.Errorf("PeriodSeconds = %d, want 10", probe.PeriodSeconds)
+				}
+			},
+		},
+		{
+			name: "only set fields are overridden",
+			override: &v1alpha1.ProbeSpec{
+				Path:             "/readyz",
+				FailureThreshold: 5,
+			},
+			check: func(t *testing.T, probe *corev1.Probe) {
+				if probe.HTTPGet.Path != "/readyz" {
+					t.Errorf("Path = %q, want /readyz", probe.HTTPGet.Path)
+				}
+				if probe.FailureThreshold != 5 {
+					t.Errorf("FailureThreshold = %d, want 5", probe.FailureThreshold)
+				}
+				// unset fields keep the default
+				if probe.PeriodSeconds != 10 {
+					t.Errorf("PeriodSeconds = %d, want 10 (default)", probe.PeriodSeconds)
+				}
+			},
+		},
+		{
+			name: "every field can be overridden",
+			override: &v1alpha1.ProbeSpec{
+				Path:                "/readyz",
+				Port:                8080,
+				Scheme:              "HTTP",
+				InitialDelaySeconds: 5,
+				PeriodSeconds:       15,
+				TimeoutSeconds:      2,
+				SuccessThreshold:    2,
+				FailureThreshold:    6,
+			},
+			check: func(t *testing.T, probe *corev1.Probe) {
+				if probe.HTTPGet.Path != "/readyz" {
+					t.Errorf("Path = %q, want /readyz", probe.HTTPGet.Path)
+				}
+				if probe.HTTPGet.Port != intstr.FromInt(8080) {
+					t.Errorf("Port = %v, want 8080", probe.HTTPGet.Port)
+				}
+				if probe.HTTPGet.Scheme != corev1.URIScheme("HTTP") {
+					t.Errorf("Scheme = %v, want HTTP", probe.HTTPGet.Scheme)
+				}
+				if probe.InitialDelaySeconds != 5 {
+					t.Errorf("InitialDelaySeconds = %d, want 5", probe.InitialDelaySeconds)
+				}
+				if probe.PeriodSeconds != 15 {
+					t.Errorf("PeriodSeconds = %d, want 15", probe.PeriodSeconds)
+				}
+				if probe.TimeoutSeconds != 2 {
+					t.Errorf("TimeoutSeconds = %d, want 2", probe.TimeoutSeconds)
+				}
+				if probe.SuccessThreshold != 2 {
+					t.Errorf("SuccessThreshold = %d, want 2", probe.SuccessThreshold)
+				}
+				if probe.FailureThreshold != 6 {
+					t.Errorf("FailureThreshold = %d, want 6", probe.FailureThreshold)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, applyProbeOverrides(defaultProbe(), tt.override))
+		})
+	}
+}
+
+func containerDeployment(c corev1.Container) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{c},
+				},
+			},
+		},
+	}
+}
+
+func TestProbesChanged(t *testing.T) {
+	current := containerDeployment(corev1.Container{ReadinessProbe: defaultProbe()})
+	wantedSame := containerDeployment(corev1.Container{ReadinessProbe: defaultProbe()})
+	if probesChanged(current, wantedSame) {
+		t.Error("probesChanged = true for identical probes, want false")
+	}
+
+	wantedDifferent := containerDeployment(corev1.Container{ReadinessProbe: livenessProbe()})
+	if !probesChanged(current, wantedDifferent) {
+		t.Error("probesChanged = false for differing probes, want true")
+	}
+
+	syncProbes(current, wantedDifferent)
+	if probesChanged(current, wantedDifferent) {
+		t.Error("probesChanged = true after syncProbes, want false")
+	}
+}
+
+func schedulingDeployment(podSpec corev1.PodSpec) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: podSpec,
+			},
+		},
+	}
+}
+
+func TestSchedulingChanged(t *testing.T) {
+	base := corev1.PodSpec{
+		NodeSelector:      map[string]string{"role": "infra"},
+		PriorityClassName: "system-cluster-critical",
+		Containers: []corev1.Container{{
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+			},
+		}},
+	}
+
+	current := schedulingDeployment(base)
+	wantedSame := schedulingDeployment(base)
+	if schedulingChanged(current, wantedSame) {
+		t.Error("schedulingChanged = true for identical specs, want false")
+	}
+
+	changed := base
+	changed.PriorityClassName = "system-node-critical"
+	wantedDifferent := schedulingDeployment(changed)
+	if !schedulingChanged(current, wantedDifferent) {
+		t.Error("schedulingChanged = false after PriorityClassName drift, want true")
+	}
+
+	syncScheduling(current, wantedDifferent)
+	if schedulingChanged(current, wantedDifferent) {
+		t.Error("schedulingChanged = true after syncScheduling, want false")
+	}
+}
+
+func TestRolloutStrategy(t *testing.T) {
+	t.Run("defaults to 25%/25% when unset", func(t *testing.T) {
+		strategy := rolloutStrategy(&v1alpha1.Console{})
+		want := intstr.FromString("25%")
+		if *strategy.RollingUpdate.MaxSurge != want {
+			t.Errorf("MaxSurge = %v, want %v", *strategy.RollingUpdate.MaxSurge, want)
+		}
+		if *strategy.RollingUpdate.MaxUnavailable != want {
+			t.Errorf("MaxUnavailable = %v, want %v", *strategy.RollingUpdate.MaxUnavailable, want)
+		}
+	})
+
+	t.Run("honors CR overrides", func(t *testing.T) {
+		surge := intstr.FromInt(2)
+		cr := &v1alpha1.Console{
+			Spec: v1alpha1.ConsoleSpec{
+				RolloutStrategy: &v1alpha1.RolloutStrategy{
+					MaxSurge: &surge,
+				},
+			},
+		}
+		strategy := rolloutStrategy(cr)
+		if *strategy.RollingUpdate.MaxSurge != surge {
+			t.Errorf("MaxSurge = %v, want %v", *strategy.RollingUpdate.MaxSurge, surge)
+		}
+		// MaxUnavailable wasn't overridden, so it keeps the default.
+		want := intstr.FromString("25%")
+		if *strategy.RollingUpdate.MaxUnavailable != want {
+			t.Errorf("MaxUnavailable = %v, want %v", *strategy.RollingUpdate.MaxUnavailable, want)
+		}
+	})
+}
+
+func TestDrainSeconds(t *testing.T) {
+	t.Run("defaults when unset", func(t *testing.T) {
+		if got := drainSeconds(&v1alpha1.Console{}); got != defaultDrainSeconds {
+			t.Errorf("drainSeconds = %d, want %d", got, defaultDrainSeconds)
+		}
+	})
+
+	t.Run("honors CR override", func(t *testing.T) {
+		override := int32(5)
+		cr := &v1alpha1.Console{Spec: v1alpha1.ConsoleSpec{DrainTimeoutSeconds: &override}}
+		if got := drainSeconds(cr); got != override {
+			t.Errorf("drainSeconds = %d, want %d", got, override)
+		}
+	})
+}
+
+func TestConsolePreStopLifecycle(t *testing.T) {
+	override := int32(5)
+	cr := &v1alpha1.Console{Spec: v1alpha1.ConsoleSpec{DrainTimeoutSeconds: &override}}
+	lifecycle := consolePreStopLifecycle(cr)
+
+	if lifecycle.PreStop == nil || lifecycle.PreStop.Exec == nil {
+		t.Fatal("PreStop.Exec = nil, want a SIGTERM exec hook")
+	}
+	want := "kill -TERM 1; sleep 5"
+	got := lifecycle.PreStop.Exec.Command[len(lifecycle.PreStop.Exec.Command)-1]
+	if got != want {
+		t.Errorf("PreStop command = %q, want %q", got, want)
+	}
+}
+
+func hasEnvVar(env []corev1.EnvVar, name string) bool {
+	for _, e := range env {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestConsoleDownwardEnv(t *testing.T) {
+	defer func(supported bool) { podIPsSupported = supported }(podIPsSupported)
+
+	t.Run("single-stack never adds POD_IPS", func(t *testing.T) {
+		podIPsSupported = true
+		env := consoleDownwardEnv(&v1alpha1.Console{})
+		if hasEnvVar(env, "POD_IPS") {
+			t.Error("POD_IPS present for a non-DualStack CR, want absent")
+		}
+	})
+
+	t.Run("dual-stack adds POD_IPS when the API server supports it", func(t *testing.T) {
+		podIPsSupported = true
+		env := consoleDownwardEnv(&v1alpha1.Console{Spec: v1alpha1.ConsoleSpec{DualStack: true}})
+		if !hasEnvVar(env, "POD_IPS") {
+			t.Error("POD_IPS absent for a DualStack CR on a supporting server, want present")
+		}
+	})
+
+	t.Run("dual-stack falls back to POD_IP only on an old API server", func(t *testing.T) {
+		podIPsSupported = false
+		env := consoleDownwardEnv(&v1alpha1.Console{Spec: v1alpha1.ConsoleSpec{DualStack: true}})
+		if hasEnvVar(env, "POD_IPS") {
+			t.Error("POD_IPS present despite podIPsSupported = false, want absent")
+		}
+		if !hasEnvVar(env, "POD_IP") {
+			t.Error("POD_IP absent, want present as the fallback")
+		}
+	})
+}
+
+func TestContainersChanged(t *testing.T) {
+	current := containerDeployment(corev1.Container{
+		Command: []string{"/opt/bridge/bin/bridge"},
+		Env:     []corev1.EnvVar{{Name: "POD_NAME"}},
+	})
+	wantedSame := containerDeployment(corev1.Container{
+		Command: []string{"/opt/bridge/bin/bridge"},
+		Env:     []corev1.EnvVar{{Name: "POD_NAME"}},
+	})
+	if containersChanged(current, wantedSame) {
+		t.Error("containersChanged = true for identical containers, want false")
+	}
+
+	wantedDifferent := containerDeployment(corev1.Container{
+		Command: []string{"/opt/bridge/bin/bridge", "--listen=[::]:8443"},
+		Env:     []corev1.EnvVar{{Name: "POD_NAME"}, {Name: "POD_IPS"}},
+	})
+	if !containersChanged(current, wantedDifferent) {
+		t.Error("containersChanged = false after DualStack Command/Env drift, want true")
+	}
+
+	syncContainers(current, wantedDifferent)
+	if containersChanged(current, wantedDifferent) {
+		t.Error("containersChanged = true after syncContainers, want false")
+	}
+}
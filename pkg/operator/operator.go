@@ -0,0 +1,53 @@
+package operator
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/openshift/console-operator/pkg/apis/console/v1alpha1"
+)
+
+// drainTimeout bounds how long Shutdown will wait for in-flight reconciles
+// before giving up and returning, so a stuck reconcile can't hang pod
+// termination forever. A var rather than a const so tests can shrink it.
+var drainTimeout = 25 * time.Second
+
+// reconcileWG is incremented for the duration of every reconcile so that
+// Shutdown can block until the operator is idle before the process exits.
+var reconcileWG sync.WaitGroup
+
+// BeginReconcile marks the start of a reconcile. Callers must invoke the
+// returned func when the reconcile completes.
+func BeginReconcile() func() {
+	reconcileWG.Add(1)
+	return reconcileWG.Done
+}
+
+// Shutdown waits for every reconcile in flight to drain before returning,
+// so the operator doesn't release its leader election lease or exit
+// mid-write. cr is used only to name the console in the log; it may be nil. It gives up after drainTimeout rather than blocking
+// forever. Callers should release the leader election lease only after
+// Shutdown returns.
+func Shutdown(cr *v1alpha1.Console) error {
+	name := "console"
+	if cr != nil {
+		name = cr.Name
+	}
+	logrus.Infof("shutting down console operator, draining in-flight reconciles for %q", name)
+
+	done := make(chan struct{})
+	go func() {
+		reconcileWG.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		logrus.Info("console operator reconciles drained, shutdown complete")
+	case <-time.After(drainTimeout):
+		logrus.Warnf("console operator shutdown timed out after %s waiting for reconciles to drain", drainTimeout)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package operator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShutdownWaitsForInFlightReconcile(t *testing.T) {
+	done := BeginReconcile()
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		if err := Shutdown(nil); err != nil {
+			t.Errorf("Shutdown() = %v, want nil", err)
+		}
+		close(shutdownDone)
+	}()
+
+	select {
+	case <-shutdownDone:
+		t.Fatal("Shutdown returned before the in-flight reconcile finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	done()
+
+	select {
+	case <-shutdownDone:
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown did not return after the reconcile finished")
+	}
+}
+
+func TestShutdownTimesOutIfReconcileNeverFinishes(t *testing.T) {
+	orig := drainTimeout
+	drainTimeout = 20 * time.Millisecond
+	defer func() { drainTimeout = orig }()
+
+	done := BeginReconcile()
+	defer done()
+
+	start := time.Now()
+	if err := Shutdown(nil); err != nil {
+		t.Errorf("Shutdown() = %v, want nil", err)
+	}
+	if elapsed := time.Since(start); elapsed < drainTimeout {
+		t.Errorf("Shutdown returned after %s, want at least %s", elapsed, drainTimeout)
+	}
+}
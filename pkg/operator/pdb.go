@@ -0,0 +1,100 @@
+package operator
+
+import (
+	// standard lib
+	"reflect"
+
+	// 3rd party
+	"github.com/sirupsen/logrus"
+
+	// kubernetes
+	policyv1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	// openshift
+	"github.com/operator-framework/operator-sdk/pkg/sdk"
+
+	// operator
+	"github.com/openshift/console-operator/pkg/apis/console/v1alpha1"
+)
+
+// minAvailableReplicas computes how many console replicas must stay
+// available during a voluntary disruption: max(1, replicas-1), so a
+// single-replica console still gets a (lesser) protection against
+// concurrent drains.
+func minAvailableReplicas(replicas int32) int32 {
+	minAvailable := replicas - 1
+	if minAvailable < 1 {
+		minAvailable = 1
+	}
+	return minAvailable
+}
+
+// newConsolePDB builds the PodDisruptionBudget that keeps a voluntary node
+// drain from ever taking the console to zero replicas.
+func newConsolePDB(cr *v1alpha1.Console) *policyv1.PodDisruptionBudget {
+	labels := labelsForConsole()
+	meta := sharedMeta()
+	meta.Labels = labels
+
+	minAvailableIntStr := intstr.FromInt(int(minAvailableReplicas(cr.Spec.Count)))
+
+	pdb := &policyv1.PodDisruptionBudget{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "policy/v1beta1",
+			Kind:       "PodDisruptionBudget",
+		},
+		ObjectMeta: meta,
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailableIntStr,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+		},
+	}
+	addOwnerRef(pdb, ownerRefFrom(cr))
+	return pdb
+}
+
+func CreatePDB(cr *v1alpha1.Console) (*policyv1.PodDisruptionBudget, error) {
+	pdb := newConsolePDB(cr)
+	if err := sdk.Create(pdb); err != nil && !errors.IsAlreadyExists(err) {
+		logrus.Errorf("failed to create console pod disruption budget : %v", err)
+		return nil, err
+	}
+	logrus.Infof("created console pod disruption budget '%s'", pdb.ObjectMeta.Name)
+	return pdb, nil
+}
+
+func UpdatePDB(cr *v1alpha1.Console) (*policyv1.PodDisruptionBudget, error) {
+	wanted := newConsolePDB(cr)
+	pdb := newConsolePDB(cr)
+	if err := sdk.Get(pdb); err != nil {
+		return nil, err
+	}
+
+	if !reflect.DeepEqual(pdb.Spec.MinAvailable, wanted.Spec.MinAvailable) {
+		pdb.Spec.MinAvailable = wanted.Spec.MinAvailable
+		if err := sdk.Update(pdb); err != nil {
+			return nil, err
+		}
+	}
+	return pdb, nil
+}
+
+func ApplyPDB(cr *v1alpha1.Console) (*policyv1.PodDisruptionBudget, error) {
+	pdb := newConsolePDB(cr)
+	err := sdk.Get(pdb)
+	if err != nil {
+		return CreatePDB(cr)
+	}
+	return UpdatePDB(cr)
+}
+
+// Deletes the Console PodDisruptionBudget when the Console ManagementState is set to Removed
+func DeletePDB(cr *v1alpha1.Console) error {
+	pdb := newConsolePDB(cr)
+	return sdk.Delete(pdb)
+}
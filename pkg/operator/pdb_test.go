@@ -0,0 +1,22 @@
+package operator
+
+import "testing"
+
+func TestMinAvailableReplicas(t *testing.T) {
+	tests := []struct {
+		replicas int32
+		want     int32
+	}{
+		{replicas: 0, want: 1},
+		{replicas: 1, want: 1},
+		{replicas: 2, want: 1},
+		{replicas: 3, want: 2},
+		{replicas: 10, want: 9},
+	}
+
+	for _, tt := range tests {
+		if got := minAvailableReplicas(tt.replicas); got != tt.want {
+			t.Errorf("minAvailableReplicas(%d) = %d, want %d", tt.replicas, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package operator
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/operator-framework/operator-sdk/pkg/sdk"
+
+	"github.com/openshift/console-operator/pkg/apis/console/v1alpha1"
+)
+
+// podNotReadyError names the first pod/container deploymentReadiness found
+// stuck, so callers can surface something actionable instead of a bare
+// "not ready yet".
+type podNotReadyError struct {
+	pod       string
+	container string
+	reason    string
+}
+
+func (e *podNotReadyError) Error() string {
+	return fmt.Sprintf("pod %s container %s is not ready: %s", e.pod, e.container, e.reason)
+}
+
+// deploymentReadiness fetches the live console Deployment and reports
+// whether it has finished rolling out. It returns a *podNotReadyError if a
+// pod is found stuck in a crash/image-pull loop.
+func deploymentReadiness(cr *v1alpha1.Console) (bool, *appsv1.Deployment, error) {
+	d := newConsoleDeployment(cr)
+	if err := sdk.Get(d); err != nil {
+		return false, nil, err
+	}
+
+	if rolloutComplete(d) {
+		return true, d, nil
+	}
+
+	if err := checkPodsHealthy(cr); err != nil {
+		return false, d, err
+	}
+	return false, d, nil
+}
+
+// rolloutComplete reports whether d's status shows the rollout has finished:
+// the controller has observed the latest spec and every replica is both
+// updated and available.
+func rolloutComplete(d *appsv1.Deployment) bool {
+	var wantReplicas int32
+	if d.Spec.Replicas != nil {
+		wantReplicas = *d.Spec.Replicas
+	}
+	return d.Status.ObservedGeneration >= d.Generation &&
+		d.Status.UpdatedReplicas == wantReplicas &&
+		d.Status.AvailableReplicas == wantReplicas
+}
+
+// checkPodsHealthy lists the console pods and returns a *podNotReadyError
+// for the first container found waiting on a crash/image-pull loop.
+func checkPodsHealthy(cr *v1alpha1.Console) error {
+	pods := &corev1.PodList{}
+	selector := metav1.LabelSelector{MatchLabels: labelsForConsole()}
+	if err := sdk.List(cr.Namespace, pods, sdk.WithListOptions(&metav1.ListOptions{
+		LabelSelector: metav1.FormatLabelSelector(&selector),
+	})); err != nil {
+		return err
+	}
+	if podErr := firstUnhealthyPod(pods); podErr != nil {
+		return podErr
+	}
+	return nil
+}
+
+// firstUnhealthyPod returns a *podNotReadyError for the first container
+// across pods found waiting on a crash/image-pull loop, or nil if none is.
+func firstUnhealthyPod(pods *corev1.PodList) *podNotReadyError {
+	for _, pod := range pods.Items {
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.State.Waiting == nil {
+				continue
+			}
+			switch cs.State.Waiting.Reason {
+			case "CrashLoopBackOff", "ImagePullBackOff", "ErrImagePull":
+				return &podNotReadyError{pod: pod.Name, container: cs.Name, reason: cs.State.Waiting.Reason}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package operator
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRolloutComplete(t *testing.T) {
+	replicas := int32(3)
+	tests := []struct {
+		name string
+		d    *appsv1.Deployment
+		want bool
+	}{
+		{
+			name: "fully rolled out",
+			d: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{Replicas: &replicas},
+				Status: appsv1.DeploymentStatus{
+					ObservedGeneration: 2,
+					UpdatedReplicas:    3,
+					AvailableReplicas:  3,
+				},
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+			},
+			want: true,
+		},
+		{
+			name: "stale observed generation",
+			d: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{Replicas: &replicas},
+				Status: appsv1.DeploymentStatus{
+					ObservedGeneration: 1,
+					UpdatedReplicas:    3,
+					AvailableReplicas:  3,
+				},
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+			},
+			want: false,
+		},
+		{
+			name: "not all replicas available yet",
+			d: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{Replicas: &replicas},
+				Status: appsv1.DeploymentStatus{
+					ObservedGeneration: 2,
+					UpdatedReplicas:    3,
+					AvailableReplicas:  2,
+				},
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rolloutComplete(tt.d); got != tt.want {
+				t.Errorf("rolloutComplete() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirstUnhealthyPod(t *testing.T) {
+	t.Run("no pods", func(t *testing.T) {
+		if got := firstUnhealthyPod(&corev1.PodList{}); got != nil {
+			t.Errorf("firstUnhealthyPod() = %v, want nil", got)
+		}
+	})
+
+	t.Run("healthy pods", func(t *testing.T) {
+		pods := &corev1.PodList{Items: []corev1.Pod{
+			{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "console", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+			}}},
+		}}
+		if got := firstUnhealthyPod(pods); got != nil {
+			t.Errorf("firstUnhealthyPod() = %v, want nil", got)
+		}
+	})
+
+	t.Run("crash looping container", func(t *testing.T) {
+		pods := &corev1.PodList{Items: []corev1.Pod{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "console-1"},
+				Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "console", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+				}},
+			},
+		}}
+		got := firstUnhealthyPod(pods)
+		if got == nil {
+			t.Fatal("firstUnhealthyPod() = nil, want a *podNotReadyError")
+		}
+		if got.pod != "console-1" || got.container != "console" || got.reason != "CrashLoopBackOff" {
+			t.Errorf("firstUnhealthyPod() = %+v, want pod=console-1 container=console reason=CrashLoopBackOff", got)
+		}
+	})
+
+	t.Run("waiting for a benign reason is not unhealthy", func(t *testing.T) {
+		pods := &corev1.PodList{Items: []corev1.Pod{
+			{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "console", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}}},
+			}}},
+		}}
+		if got := firstUnhealthyPod(pods); got != nil {
+			t.Errorf("firstUnhealthyPod() = %v, want nil", got)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package operator
+
+import (
+	"errors"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/operator-framework/operator-sdk/pkg/sdk"
+
+	"github.com/openshift/console-operator/pkg/apis/console/v1alpha1"
+)
+
+// ErrDeploymentProgressing is returned by UpdateConsoleStatus while the
+// console Deployment has not finished rolling out, so the reconcile loop
+// can requeue with backoff instead of reporting success prematurely.
+var ErrDeploymentProgressing = errors.New("console deployment is still progressing")
+
+// UpdateConsoleStatus checks the console Deployment's rollout state and
+// records Available/Progressing/Degraded conditions on cr, following the
+// standard operator conventions. It returns ErrDeploymentProgressing while
+// the rollout is still in flight.
+func UpdateConsoleStatus(cr *v1alpha1.Console) error {
+	ready, _, err := deploymentReadiness(cr)
+	now := metav1.Now()
+
+	inProgress, condErr := applyStatusConditions(cr, ready, err, now)
+	if condErr != nil {
+		return condErr
+	}
+
+	if err := sdk.Update(cr); err != nil {
+		return err
+	}
+	if inProgress {
+		return ErrDeploymentProgressing
+	}
+	return nil
+}
+
+// applyStatusConditions updates cr's Available/Progressing/Degraded
+// conditions for a deploymentReadiness result, returning whether the
+// rollout should still be treated as in progress. It returns an error only
+// when readyErr is itself an error other than a *podNotReadyError, in which
+// case cr's conditions are left untouched.
+func applyStatusConditions(cr *v1alpha1.Console, ready bool, readyErr error, now metav1.Time) (inProgress bool, err error) {
+	var podErr *podNotReadyError
+	switch {
+	case readyErr == nil && ready:
+		setCondition(cr, v1alpha1.ConditionAvailable, corev1.ConditionTrue, "DeploymentReady", "", now)
+		setCondition(cr, v1alpha1.ConditionProgressing, corev1.ConditionFalse, "DeploymentReady", "", now)
+		setCondition(cr, v1alpha1.ConditionDegraded, corev1.ConditionFalse, "DeploymentReady", "", now)
+		return false, nil
+	case errors.As(readyErr, &podErr):
+		setCondition(cr, v1alpha1.ConditionAvailable, corev1.ConditionFalse, "PodNotReady", readyErr.Error(), now)
+		setCondition(cr, v1alpha1.ConditionProgressing, corev1.ConditionTrue, "PodNotReady", readyErr.Error(), now)
+		setCondition(cr, v1alpha1.ConditionDegraded, corev1.ConditionTrue, "PodNotReady", readyErr.Error(), now)
+		return true, nil
+	case readyErr != nil:
+		return false, readyErr
+	default:
+		// The rollout is in flight but no pod is stuck, so clear Degraded
+		// explicitly rather than leaving a stale True from an earlier
+		// reconcile where a pod had been crash-looping.
+		reason := "RolloutInProgress"
+		setCondition(cr, v1alpha1.ConditionProgressing, corev1.ConditionTrue, reason, "", now)
+		setCondition(cr, v1alpha1.ConditionDegraded, corev1.ConditionFalse, reason, "", now)
+		return true, nil
+	}
+}
+
+// setCondition upserts a condition by type, bumping LastTransitionTime only
+// when the status actually flips.
+func setCondition(cr *v1alpha1.Console, t v1alpha1.ConsoleConditionType, status corev1.ConditionStatus, reason, message string, now metav1.Time) {
+	for i := range cr.Status.Conditions {
+		if cr.Status.Conditions[i].Type != t {
+			continue
+		}
+		if cr.Status.Conditions[i].Status != status {
+			cr.Status.Conditions[i].LastTransitionTime = now
+		}
+		cr.Status.Conditions[i].Status = status
+		cr.Status.Conditions[i].Reason = reason
+		cr.Status.Conditions[i].Message = message
+		return
+	}
+	cr.Status.Conditions = append(cr.Status.Conditions, v1alpha1.ConsoleCondition{
+		Type:               t,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: now,
+	})
+}
@@ -0,0 +1,127 @@
+package operator
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/console-operator/pkg/apis/console/v1alpha1"
+)
+
+func conditionStatus(cr *v1alpha1.Console, t v1alpha1.ConsoleConditionType) corev1.ConditionStatus {
+	for _, c := range cr.Status.Conditions {
+		if c.Type == t {
+			return c.Status
+		}
+	}
+	return ""
+}
+
+func TestApplyStatusConditions(t *testing.T) {
+	now := metav1.Now()
+
+	t.Run("ready clears Progressing and Degraded", func(t *testing.T) {
+		cr := &v1alpha1.Console{}
+		inProgress, err := applyStatusConditions(cr, true, nil, now)
+		if err != nil {
+			t.Fatalf("applyStatusConditions() error = %v", err)
+		}
+		if inProgress {
+			t.Error("inProgress = true, want false")
+		}
+		if got := conditionStatus(cr, v1alpha1.ConditionAvailable); got != corev1.ConditionTrue {
+			t.Errorf("Available = %v, want True", got)
+		}
+		if got := conditionStatus(cr, v1alpha1.ConditionProgressing); got != corev1.ConditionFalse {
+			t.Errorf("Progressing = %v, want False", got)
+		}
+		if got := conditionStatus(cr, v1alpha1.ConditionDegraded); got != corev1.ConditionFalse {
+			t.Errorf("Degraded = %v, want False", got)
+		}
+	})
+
+	t.Run("pod not ready sets Degraded", func(t *testing.T) {
+		cr := &v1alpha1.Console{}
+		podErr := &podNotReadyError{pod: "console-1", container: "console", reason: "CrashLoopBackOff"}
+		inProgress, err := applyStatusConditions(cr, false, podErr, now)
+		if err != nil {
+			t.Fatalf("applyStatusConditions() error = %v", err)
+		}
+		if !inProgress {
+			t.Error("inProgress = false, want true")
+		}
+		if got := conditionStatus(cr, v1alpha1.ConditionDegraded); got != corev1.ConditionTrue {
+			t.Errorf("Degraded = %v, want True", got)
+		}
+		if got := conditionStatus(cr, v1alpha1.ConditionAvailable); got != corev1.ConditionFalse {
+			t.Errorf("Available = %v, want False", got)
+		}
+		if got := conditionStatus(cr, v1alpha1.ConditionProgressing); got != corev1.ConditionTrue {
+			t.Errorf("Progressing = %v, want True", got)
+		}
+	})
+
+	t.Run("still rolling out clears a stale Degraded", func(t *testing.T) {
+		cr := &v1alpha1.Console{Status: v1alpha1.ConsoleStatus{Conditions: []v1alpha1.ConsoleCondition{
+			{Type: v1alpha1.ConditionDegraded, Status: corev1.ConditionTrue},
+		}}}
+		inProgress, err := applyStatusConditions(cr, false, nil, now)
+		if err != nil {
+			t.Fatalf("applyStatusConditions() error = %v", err)
+		}
+		if !inProgress {
+			t.Error("inProgress = false, want true")
+		}
+		if got := conditionStatus(cr, v1alpha1.ConditionDegraded); got != corev1.ConditionFalse {
+			t.Errorf("Degraded = %v, want False (cleared)", got)
+		}
+		if got := conditionStatus(cr, v1alpha1.ConditionProgressing); got != corev1.ConditionTrue {
+			t.Errorf("Progressing = %v, want True", got)
+		}
+	})
+
+	t.Run("a non-pod error is returned untouched", func(t *testing.T) {
+		cr := &v1alpha1.Console{}
+		wantErr := errors.New("boom")
+		_, err := applyStatusConditions(cr, false, wantErr, now)
+		if err != wantErr {
+			t.Errorf("applyStatusConditions() error = %v, want %v", err, wantErr)
+		}
+		if len(cr.Status.Conditions) != 0 {
+			t.Errorf("Conditions = %v, want untouched", cr.Status.Conditions)
+		}
+	})
+}
+
+func TestSetCondition(t *testing.T) {
+	cr := &v1alpha1.Console{}
+	now := metav1.Now()
+
+	setCondition(cr, v1alpha1.ConditionAvailable, corev1.ConditionFalse, "Initial", "", now)
+	if len(cr.Status.Conditions) != 1 {
+		t.Fatalf("Conditions = %v, want 1 entry", cr.Status.Conditions)
+	}
+	first := cr.Status.Conditions[0].LastTransitionTime
+
+	// Same status: LastTransitionTime must not move.
+	later := metav1.NewTime(now.Add(time.Minute))
+	setCondition(cr, v1alpha1.ConditionAvailable, corev1.ConditionFalse, "StillInitial", "", later)
+	if cr.Status.Conditions[0].LastTransitionTime != first {
+		t.Error("LastTransitionTime changed on a same-status update")
+	}
+	if cr.Status.Conditions[0].Reason != "StillInitial" {
+		t.Errorf("Reason = %q, want StillInitial", cr.Status.Conditions[0].Reason)
+	}
+
+	// Status flips: LastTransitionTime must update.
+	setCondition(cr, v1alpha1.ConditionAvailable, corev1.ConditionTrue, "NowReady", "", later)
+	if cr.Status.Conditions[0].LastTransitionTime != later {
+		t.Error("LastTransitionTime did not update on a status flip")
+	}
+	if len(cr.Status.Conditions) != 1 {
+		t.Errorf("Conditions = %v, want still 1 entry (upsert, not append)", cr.Status.Conditions)
+	}
+}